@@ -0,0 +1,310 @@
+package parsesvg
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/pbnjay/memory"
+)
+
+// defaultMaxEntries bounds cache size when LayoutCacheOptions.MaxEntries
+// is left at zero.
+const defaultMaxEntries = 128
+
+// envMemoryLimitGB overrides the default memory budget (1/4 of system
+// RAM), expressed in gigabytes, e.g. PARSESVG_MEMORYLIMIT=2.
+const envMemoryLimitGB = "PARSESVG_MEMORYLIMIT"
+
+// LayoutCacheOptions configures a LayoutCache. The zero value selects
+// defaultMaxEntries entries and a memory budget of 1/4 of system RAM,
+// overridable via PARSESVG_MEMORYLIMIT.
+type LayoutCacheOptions struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// LayoutCacheStats reports the current state of a LayoutCache.
+type LayoutCacheStats struct {
+	Entries   int
+	Bytes     int64
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// LayoutCache wraps DefineLayoutFromSVG with an LRU cache keyed by the
+// content hash of the input bytes, evicting least-recently-used entries
+// once the cache exceeds either its entry count or its memory budget.
+// This matters for services that repeatedly render the same handful of
+// layout templates against many student submissions. A LayoutCache is
+// safe for concurrent use.
+type LayoutCache struct {
+	mu sync.Mutex
+
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+
+	ll    *list.List
+	items map[string]*list.Element
+
+	stats LayoutCacheStats
+}
+
+type layoutCacheEntry struct {
+	hash   string
+	layout *Layout
+	size   int64
+}
+
+// NewLayoutCache creates a LayoutCache. Passing the zero LayoutCacheOptions
+// selects the defaults described on LayoutCacheOptions.
+func NewLayoutCache(opts LayoutCacheOptions) *LayoutCache {
+
+	maxEntries := opts.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes()
+	}
+
+	return &LayoutCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// defaultMaxBytes is 1/4 of system RAM, unless PARSESVG_MEMORYLIMIT gives
+// an override in gigabytes.
+func defaultMaxBytes() int64 {
+	if s := os.Getenv(envMemoryLimitGB); s != "" {
+		if gb, err := strconv.ParseFloat(s, 64); err == nil && gb > 0 {
+			return int64(gb * (1 << 30))
+		}
+	}
+	return int64(memory.TotalMemory() / 4)
+}
+
+// GetOrParse returns the cached Layout for input if present, else parses
+// it with DefineLayoutFromSVG, caches the result and returns it. key is
+// used only to annotate parse errors; cache identity comes from the
+// content hash of input, so two calls with different keys but identical
+// bytes share one cache entry. Each call gets its own copy of the Layout
+// -- the cache entry's maps and slices are never handed out directly --
+// so callers are free to mutate what they get back (e.g. running a
+// LayoutTransform to customize it per submission) without corrupting the
+// shared entry for other callers.
+func (c *LayoutCache) GetOrParse(key string, input []byte) (*Layout, error) {
+
+	hash := hashInput(input)
+
+	c.mu.Lock()
+	if el, ok := c.items[hash]; ok {
+		c.ll.MoveToFront(el)
+		c.stats.Hits++
+		layout := el.Value.(*layoutCacheEntry).layout
+		c.mu.Unlock()
+		return cloneLayout(layout), nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	layout, err := DefineLayoutFromSVG(input)
+	if err != nil {
+		return nil, fmt.Errorf("parsing layout %q: %v", key, err)
+	}
+
+	entry := &layoutCacheEntry{
+		hash:   hash,
+		layout: layout,
+		size:   estimateLayoutSize(layout, len(input)),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// another goroutine may have parsed and cached the same content
+	// while we were outside the lock
+	if el, ok := c.items[hash]; ok {
+		c.ll.MoveToFront(el)
+		return cloneLayout(el.Value.(*layoutCacheEntry).layout), nil
+	}
+
+	el := c.ll.PushFront(entry)
+	c.items[hash] = el
+	c.bytes += entry.size
+
+	c.evict()
+
+	return cloneLayout(layout), nil
+}
+
+// evict drops least-recently-used entries until the cache is within both
+// its entry count and memory budget. Caller must hold c.mu.
+func (c *LayoutCache) evict() {
+	for c.ll.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		el := c.ll.Back()
+		if el == nil {
+			return
+		}
+		entry := el.Value.(*layoutCacheEntry)
+		c.ll.Remove(el)
+		delete(c.items, entry.hash)
+		c.bytes -= entry.size
+		c.stats.Evictions++
+	}
+}
+
+// Stats reports the cache's current size and hit/miss/eviction counters.
+func (c *LayoutCache) Stats() LayoutCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.ll.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Purge removes every entry from the cache.
+func (c *LayoutCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll = list.New()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+func hashInput(input []byte) string {
+	sum := sha256.Sum256(input)
+	return hex.EncodeToString(sum[:])
+}
+
+// cloneLayout deep-copies layout so a caller mutating the returned Layout
+// (or a LayoutTransform run on it) can never reach the maps and slices
+// backing a cached entry.
+func cloneLayout(layout *Layout) *Layout {
+	clone := *layout
+
+	if layout.Anchors != nil {
+		clone.Anchors = make(map[string]AnchorInfo, len(layout.Anchors))
+		for k, v := range layout.Anchors {
+			v.Metadata = cloneMetadata(v.Metadata)
+			clone.Anchors[k] = v
+		}
+	}
+	if layout.Filenames != nil {
+		clone.Filenames = make(map[string]string, len(layout.Filenames))
+		for k, v := range layout.Filenames {
+			clone.Filenames[k] = v
+		}
+	}
+	if layout.PageDimStatic != nil {
+		clone.PageDimStatic = make(map[string]DimInfo, len(layout.PageDimStatic))
+		for k, v := range layout.PageDimStatic {
+			v.Metadata = cloneMetadata(v.Metadata)
+			clone.PageDimStatic[k] = v
+		}
+	}
+	if layout.PageDimDynamic != nil {
+		clone.PageDimDynamic = make(map[string]DynamicDimInfo, len(layout.PageDimDynamic))
+		for k, v := range layout.PageDimDynamic {
+			v.Metadata = cloneMetadata(v.Metadata)
+			clone.PageDimDynamic[k] = v
+		}
+	}
+	if layout.PreviousImageStatic != nil {
+		clone.PreviousImageStatic = make(map[string]DimInfo, len(layout.PreviousImageStatic))
+		for k, v := range layout.PreviousImageStatic {
+			v.Metadata = cloneMetadata(v.Metadata)
+			clone.PreviousImageStatic[k] = v
+		}
+	}
+	if layout.PreviousImageDynamic != nil {
+		clone.PreviousImageDynamic = make(map[string]DynamicDimInfo, len(layout.PreviousImageDynamic))
+		for k, v := range layout.PreviousImageDynamic {
+			v.Metadata = cloneMetadata(v.Metadata)
+			clone.PreviousImageDynamic[k] = v
+		}
+	}
+	if layout.Imports != nil {
+		clone.Imports = make([]ImportedLayout, len(layout.Imports))
+		copy(clone.Imports, layout.Imports)
+	}
+
+	return &clone
+}
+
+// cloneMetadata copies m's Params map so a clone never shares mutable
+// front-matter state with the Metadata it was copied from.
+func cloneMetadata(m Metadata) Metadata {
+	if m.Params == nil {
+		return m
+	}
+	clone := m
+	clone.Params = make(map[string]interface{}, len(m.Params))
+	for k, v := range m.Params {
+		clone.Params[k] = cloneParamValue(v)
+	}
+	return clone
+}
+
+// cloneParamValue deep-copies a Params value as far as YAML/TOML/JSON
+// unmarshaling can actually produce: nested maps, slices, and scalars.
+// Scalars are returned as-is since Go values (strings, numbers, bools)
+// are immutable and safe to share.
+func cloneParamValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			clone[k] = cloneParamValue(e)
+		}
+		return clone
+	case map[interface{}]interface{}:
+		clone := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			clone[k] = cloneParamValue(e)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, e := range v {
+			clone[i] = cloneParamValue(e)
+		}
+		return clone
+	default:
+		return v
+	}
+}
+
+// estimateLayoutSize approximates the memory a *Layout holds onto: the
+// raw SVG bytes it was parsed from, plus a rough per-entry cost for each
+// map so a handful of large layouts don't silently blow past the budget.
+func estimateLayoutSize(layout *Layout, inputLen int) int64 {
+
+	const avgKeyBytes = 24                             // namespaced import keys run longer than bare names
+	const pointEntryBytes = avgKeyBytes + 16 + 48      // key + geo.Point + map bucket overhead
+	const dimEntryBytes = avgKeyBytes + 16 + 48        // key + geo.Dim + map bucket overhead
+	const dynamicDimEntryBytes = avgKeyBytes + 24 + 48 // key + geo.DynamicDim + map bucket overhead
+	const stringEntryBytes = avgKeyBytes + 32 + 48     // key + typical filename + map bucket overhead
+
+	size := int64(inputLen)
+	size += int64(len(layout.Anchors)) * pointEntryBytes
+	size += int64(len(layout.Filenames)) * stringEntryBytes
+	size += int64(len(layout.PageDimStatic)) * dimEntryBytes
+	size += int64(len(layout.PageDimDynamic)) * dynamicDimEntryBytes
+	size += int64(len(layout.PreviousImageStatic)) * dimEntryBytes
+	size += int64(len(layout.PreviousImageDynamic)) * dynamicDimEntryBytes
+
+	return size
+}
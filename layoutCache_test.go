@@ -0,0 +1,125 @@
+package parsesvg
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/timdrysdale/geo"
+)
+
+// newTestCache builds a LayoutCache with the given bounds and seeds it with
+// n entries of the given size, oldest first, without going through
+// GetOrParse (which requires a real SVG parse). This lets eviction, Stats
+// and Purge be exercised directly against the cache's bookkeeping.
+func newTestCache(maxEntries int, maxBytes int64, sizes ...int64) *LayoutCache {
+	c := &LayoutCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+	for i, size := range sizes {
+		hash := string(rune('a' + i))
+		entry := &layoutCacheEntry{hash: hash, layout: &Layout{ID: hash}, size: size}
+		el := c.ll.PushFront(entry)
+		c.items[hash] = el
+		c.bytes += size
+	}
+	return c
+}
+
+func TestLayoutCacheEvictByCount(t *testing.T) {
+	c := newTestCache(2, 1<<30, 10, 10, 10)
+
+	c.evict()
+
+	if got := c.ll.Len(); got != 2 {
+		t.Errorf("entries after evict = %d, want 2", got)
+	}
+	if got := c.Stats().Evictions; got != 1 {
+		t.Errorf("evictions = %d, want 1", got)
+	}
+	// the oldest entry (pushed first, so at the back of the list) must be
+	// the one dropped
+	if _, ok := c.items["a"]; ok {
+		t.Errorf("oldest entry %q should have been evicted", "a")
+	}
+}
+
+func TestLayoutCacheEvictByBytes(t *testing.T) {
+	c := newTestCache(10, 25, 10, 10, 10)
+
+	c.evict()
+
+	stats := c.Stats()
+	if stats.Bytes > 25 {
+		t.Errorf("bytes after evict = %d, want <= 25", stats.Bytes)
+	}
+	if stats.Entries != 2 {
+		t.Errorf("entries after evict = %d, want 2", stats.Entries)
+	}
+}
+
+func TestLayoutCachePurge(t *testing.T) {
+	c := newTestCache(10, 1<<30, 10, 20)
+
+	c.Purge()
+
+	stats := c.Stats()
+	if stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("stats after Purge = %+v, want zero entries and bytes", stats)
+	}
+	if len(c.items) != 0 {
+		t.Errorf("items after Purge = %d, want 0", len(c.items))
+	}
+}
+
+func TestLayoutCacheStatsTracksHitsAndMisses(t *testing.T) {
+	c := newTestCache(10, 1<<30)
+	c.stats.Hits = 3
+	c.stats.Misses = 2
+
+	stats := c.Stats()
+
+	if stats.Hits != 3 || stats.Misses != 2 {
+		t.Errorf("stats = %+v, want Hits=3 Misses=2", stats)
+	}
+}
+
+// TestCloneLayoutDoesNotShareParams guards against the regression fixed in
+// cloneLayout/cloneMetadata: a clone handed to one caller must not share
+// mutable Metadata.Params state with the cached entry, including nested
+// maps and slices inside Params.
+func TestCloneLayoutDoesNotShareParams(t *testing.T) {
+	original := &Layout{
+		Anchors: map[string]AnchorInfo{
+			"a": {
+				Point: geo.Point{X: 1, Y: 2},
+				Metadata: Metadata{
+					Name: "a",
+					Params: map[string]interface{}{
+						"nested": map[string]interface{}{"colour": "red"},
+						"list":   []interface{}{"x", "y"},
+					},
+				},
+			},
+		},
+	}
+
+	clone := cloneLayout(original)
+
+	clone.Anchors["a"].Metadata.Params["nested"].(map[string]interface{})["colour"] = "blue"
+	clone.Anchors["a"].Metadata.Params["list"].([]interface{})[0] = "mutated"
+	clone.Anchors["a"].Metadata.Params["top"] = "added"
+
+	origParams := original.Anchors["a"].Metadata.Params
+	if got := origParams["nested"].(map[string]interface{})["colour"]; got != "red" {
+		t.Errorf("nested param colour = %v, want %q (mutation leaked into cached entry)", got, "red")
+	}
+	if got := origParams["list"].([]interface{})[0]; got != "x" {
+		t.Errorf("list param[0] = %v, want %q (mutation leaked into cached entry)", got, "x")
+	}
+	if _, ok := origParams["top"]; ok {
+		t.Errorf("key added on clone leaked into cached entry's Params")
+	}
+}
@@ -0,0 +1,105 @@
+package parsesvg
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/timdrysdale/geo"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Metadata is optional structured front matter parsed from an SVG
+// element's <desc>, borrowing Hugo's resource front-matter idea: a Name
+// and Title for the element, plus free-form Params for anything else
+// (validation rules, colours, font hints, default values, grouping
+// labels) a particular layout needs without inventing another Inkscape
+// layer convention.
+type Metadata struct {
+	Name   string
+	Title  string
+	Params map[string]interface{}
+}
+
+// AnchorInfo is an anchor position together with any Metadata found in
+// the anchor's <desc>.
+type AnchorInfo struct {
+	geo.Point
+	Metadata Metadata
+}
+
+// DimInfo is a static page or previous-image size together with any
+// Metadata found in its <desc>.
+type DimInfo struct {
+	geo.Dim
+	Metadata Metadata
+}
+
+// DynamicDimInfo is a dynamic page or previous-image size together with
+// any Metadata found in its <desc>.
+type DynamicDimInfo struct {
+	geo.DynamicDim
+	Metadata Metadata
+}
+
+// parseMetadata looks for a leading fenced front-matter block in desc:
+// "---\n...\n---" for YAML, "+++\n...\n+++" for TOML, or a bare "{...}"
+// for JSON. It returns the decoded Metadata and whatever text follows
+// the block (trimmed), which callers treat the way they always have --
+// typically as a plain filename. If desc carries no front matter at all,
+// it is returned unchanged as remainder and Metadata is the zero value,
+// preserving the current plain-filename behaviour.
+func parseMetadata(desc string) (Metadata, string, error) {
+
+	trimmed := strings.TrimLeft(desc, "\r\n\t ")
+
+	switch {
+	case strings.HasPrefix(trimmed, "---"):
+		body, remainder, ok := splitFence(trimmed, "---")
+		if !ok {
+			return Metadata{}, desc, nil
+		}
+		var m Metadata
+		if err := yaml.Unmarshal([]byte(body), &m); err != nil {
+			return Metadata{}, "", err
+		}
+		return m, remainder, nil
+
+	case strings.HasPrefix(trimmed, "+++"):
+		body, remainder, ok := splitFence(trimmed, "+++")
+		if !ok {
+			return Metadata{}, desc, nil
+		}
+		var m Metadata
+		if err := toml.Unmarshal([]byte(body), &m); err != nil {
+			return Metadata{}, "", err
+		}
+		return m, remainder, nil
+
+	case strings.HasPrefix(trimmed, "{"):
+		var m Metadata
+		dec := json.NewDecoder(strings.NewReader(trimmed))
+		if err := dec.Decode(&m); err != nil {
+			return Metadata{}, "", err
+		}
+		return m, strings.TrimSpace(trimmed[dec.InputOffset():]), nil
+
+	default:
+		return Metadata{}, desc, nil
+	}
+}
+
+// splitFence pulls the body out of a "<fence>\n...\n<fence>" block at the
+// start of s, and returns whatever comes after the closing fence, trimmed.
+// ok is false if the closing fence is never found, in which case callers
+// should fall back to treating s as plain content.
+func splitFence(s, fence string) (body string, remainder string, ok bool) {
+	rest := strings.TrimPrefix(s, fence)
+	idx := strings.Index(rest, "\n"+fence)
+	if idx < 0 {
+		return "", "", false
+	}
+	body = strings.Trim(rest[:idx], "\r\n")
+	remainder = strings.TrimSpace(rest[idx+len("\n"+fence):])
+	return body, remainder, true
+}
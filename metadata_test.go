@@ -0,0 +1,88 @@
+package parsesvg
+
+import "testing"
+
+func TestParseMetadataYAMLFence(t *testing.T) {
+	desc := "---\nname: header\ntitle: Header\nparams:\n  colour: red\n---\nheader.png"
+
+	m, remainder, err := parseMetadata(desc)
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if m.Name != "header" || m.Title != "Header" {
+		t.Errorf("got Name=%q Title=%q, want Name=%q Title=%q", m.Name, m.Title, "header", "Header")
+	}
+	if m.Params["colour"] != "red" {
+		t.Errorf("Params[colour] = %v, want %q", m.Params["colour"], "red")
+	}
+	if remainder != "header.png" {
+		t.Errorf("remainder = %q, want %q", remainder, "header.png")
+	}
+}
+
+func TestParseMetadataTOMLFence(t *testing.T) {
+	desc := "+++\nname = \"header\"\n+++\nheader.png"
+
+	m, remainder, err := parseMetadata(desc)
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if m.Name != "header" {
+		t.Errorf("Name = %q, want %q", m.Name, "header")
+	}
+	if remainder != "header.png" {
+		t.Errorf("remainder = %q, want %q", remainder, "header.png")
+	}
+}
+
+func TestParseMetadataJSONBlock(t *testing.T) {
+	desc := `{"name": "header", "params": {"colour": "red"}}`
+
+	m, remainder, err := parseMetadata(desc)
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if m.Name != "header" {
+		t.Errorf("Name = %q, want %q", m.Name, "header")
+	}
+	if m.Params["colour"] != "red" {
+		t.Errorf("Params[colour] = %v, want %q", m.Params["colour"], "red")
+	}
+	if remainder != "" {
+		t.Errorf("remainder = %q, want empty", remainder)
+	}
+}
+
+// TestParseMetadataPlainFilename covers the compatibility path: a desc
+// with no recognised fence is returned unchanged as remainder with a
+// zero-value Metadata, preserving pre-front-matter behaviour.
+func TestParseMetadataPlainFilename(t *testing.T) {
+	m, remainder, err := parseMetadata("header.png")
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if m.Name != "" || m.Title != "" || m.Params != nil {
+		t.Errorf("Metadata = %+v, want zero value", m)
+	}
+	if remainder != "header.png" {
+		t.Errorf("remainder = %q, want %q", remainder, "header.png")
+	}
+}
+
+// TestParseMetadataUnterminatedFence covers a leading "---" that never
+// closes: splitFence reports ok=false and parseMetadata falls back to
+// treating the whole string as a plain remainder rather than erroring.
+func TestParseMetadataUnterminatedFence(t *testing.T) {
+	desc := "---\nname: header\nheader.png"
+
+	m, remainder, err := parseMetadata(desc)
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if m.Name != "" || m.Title != "" || m.Params != nil {
+		t.Errorf("Metadata = %+v, want zero value", m)
+	}
+	if remainder != desc {
+		t.Errorf("remainder = %q, want original desc %q", remainder, desc)
+	}
+}
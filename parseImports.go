@@ -0,0 +1,226 @@
+package parsesvg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/timdrysdale/geo"
+)
+
+// importsLayerLabel is the inkscape:label used on the layer that holds
+// import anchors, following the same convention as geo.AnchorsLayer,
+// geo.PagesLayer and geo.ImagesLayer.
+const importsLayerLabel = "imports"
+
+// importTitlePrefix marks a <rect> or <path> on the imports layer as
+// referencing another layout SVG, e.g. title="import-intro".
+const importTitlePrefix = "import-"
+
+// ImportedLayout records a sub-layout that was merged into a parent
+// Layout, for introspection after DefineLayoutFromSVGWithImports returns.
+type ImportedLayout struct {
+	Name   string    // the <name> in import-<name>
+	Ref    string    // the path/URL given in <desc>, as passed to the LayoutLoader
+	Anchor geo.Point // the parent anchor the child layout was mounted at
+}
+
+// LayoutLoader resolves the reference given in an import's <desc> (a file
+// path, URL, or embedded-FS name) to the raw bytes of the imported layout
+// SVG. Callers supply the implementation that matches how their layouts
+// are stored; FileLayoutLoader covers the common case of reading from disk.
+type LayoutLoader interface {
+	Load(ref string) ([]byte, error)
+}
+
+// FileLayoutLoader resolves import references as paths relative to Dir.
+type FileLayoutLoader struct {
+	Dir string
+}
+
+func (l FileLayoutLoader) Load(ref string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(l.Dir, ref))
+}
+
+// DefineLayoutFromSVGWithImports parses input as DefineLayoutFromSVG does,
+// then resolves any `imports` layer, merging each referenced layout's
+// Anchor, Anchors, PageDimStatic/Dynamic, PreviousImageStatic/Dynamic and
+// Filenames into the parent's coordinate space. Merged keys are namespaced
+// as "<name>/<key>" to avoid collisions between imports, or with the
+// parent's own entries.
+func DefineLayoutFromSVGWithImports(input []byte, loader LayoutLoader) (*Layout, error) {
+	return defineLayoutFromSVGWithImports(input, loader, map[string]bool{})
+}
+
+func defineLayoutFromSVGWithImports(input []byte, loader LayoutLoader, seen map[string]bool) (*Layout, error) {
+
+	layout, err := DefineLayoutFromSVG(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var svg Csvg__svg
+	if err := xml.Unmarshal(input, &svg); err != nil {
+		return nil, err
+	}
+
+	for _, g := range svg.Cg__svg {
+		if g.AttrInkscapeSpacelabel != importsLayerLabel {
+			continue
+		}
+
+		for _, r := range g.Cpath__svg {
+			if r.Title == nil || !strings.HasPrefix(r.Title.String, importTitlePrefix) {
+				continue
+			}
+
+			name := strings.TrimPrefix(r.Title.String, importTitlePrefix)
+
+			var desc string
+			if r.Desc != nil {
+				desc = r.Desc.String
+			}
+
+			if err := resolveImport(layout, name, desc, loader, seen); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, r := range g.Crect__svg {
+			if r.Title == nil || !strings.HasPrefix(r.Title.String, importTitlePrefix) {
+				continue
+			}
+
+			name := strings.TrimPrefix(r.Title.String, importTitlePrefix)
+
+			var desc string
+			if r.Desc != nil {
+				desc = r.Desc.String
+			}
+
+			if err := resolveImport(layout, name, desc, loader, seen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return layout, nil
+}
+
+// resolveImport loads and merges the layout referenced by a single
+// import-<name> element's <desc> (desc is "" if the element had none) into
+// layout, recording it in layout.Imports. It is shared by the <path> and
+// <rect> loops in defineLayoutFromSVGWithImports, since Inkscape lets either
+// shape carry an import declaration.
+func resolveImport(layout *Layout, name, desc string, loader LayoutLoader, seen map[string]bool) error {
+	if desc == "" {
+		return fmt.Errorf("import %q has no <desc> giving a source and anchor", name)
+	}
+
+	ref, anchorName, err := parseImportDesc(desc)
+	if err != nil {
+		return fmt.Errorf("import %q: %v", name, err)
+	}
+
+	if seen[ref] {
+		return fmt.Errorf("import %q: cycle detected importing %q", name, ref)
+	}
+
+	originInfo, ok := layout.Anchors[anchorName]
+	if !ok {
+		return fmt.Errorf("import %q: parent has no anchor %q to mount at", name, anchorName)
+	}
+	origin := originInfo.Point
+
+	childBytes, err := loader.Load(ref)
+	if err != nil {
+		return fmt.Errorf("import %q: loading %q: %v", name, ref, err)
+	}
+
+	childSeen := make(map[string]bool, len(seen)+1)
+	for k := range seen {
+		childSeen[k] = true
+	}
+	childSeen[ref] = true
+
+	child, err := defineLayoutFromSVGWithImports(childBytes, loader, childSeen)
+	if err != nil {
+		return err
+	}
+
+	mergeImportedLayout(layout, child, name, origin)
+
+	layout.Imports = append(layout.Imports, ImportedLayout{
+		Name:   name,
+		Ref:    ref,
+		Anchor: origin,
+	})
+
+	return nil
+}
+
+// parseImportDesc splits a <desc> of the form "path/to/child.svg@anchorName"
+// into the reference to load and the name of the anchor in the parent
+// layout that the child should be mounted at.
+func parseImportDesc(desc string) (ref string, anchor string, err error) {
+	parts := strings.SplitN(desc, "@", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected \"path@anchor\", got %q", desc)
+	}
+	return parts[0], parts[1], nil
+}
+
+// mergeImportedLayout translates child's maps into parent's coordinate
+// space, using origin as the position of child.Anchor, and namespaces
+// every merged key as "<name>/<key>".
+func mergeImportedLayout(parent, child *Layout, name string, origin geo.Point) {
+	dx := origin.X - child.Anchor.X
+	dy := origin.Y - child.Anchor.Y
+
+	if parent.Anchors == nil {
+		parent.Anchors = make(map[string]AnchorInfo)
+	}
+	for k, v := range child.Anchors {
+		parent.Anchors[name+"/"+k] = AnchorInfo{
+			Point:    geo.Point{X: v.X + dx, Y: v.Y + dy},
+			Metadata: v.Metadata,
+		}
+	}
+
+	if parent.Filenames == nil {
+		parent.Filenames = make(map[string]string)
+	}
+	for k, v := range child.Filenames {
+		parent.Filenames[name+"/"+k] = v
+	}
+
+	if parent.PageDimStatic == nil {
+		parent.PageDimStatic = make(map[string]DimInfo)
+	}
+	for k, v := range child.PageDimStatic {
+		parent.PageDimStatic[name+"/"+k] = v
+	}
+
+	if parent.PageDimDynamic == nil {
+		parent.PageDimDynamic = make(map[string]DynamicDimInfo)
+	}
+	for k, v := range child.PageDimDynamic {
+		parent.PageDimDynamic[name+"/"+k] = v
+	}
+
+	if parent.PreviousImageStatic == nil {
+		parent.PreviousImageStatic = make(map[string]DimInfo)
+	}
+	for k, v := range child.PreviousImageStatic {
+		parent.PreviousImageStatic[name+"/"+k] = v
+	}
+
+	if parent.PreviousImageDynamic == nil {
+		parent.PreviousImageDynamic = make(map[string]DynamicDimInfo)
+	}
+	for k, v := range child.PreviousImageDynamic {
+		parent.PreviousImageDynamic[name+"/"+k] = v
+	}
+}
@@ -0,0 +1,103 @@
+package parsesvg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/timdrysdale/geo"
+)
+
+// nopLoader never gets asked to load anything in tests that fail before
+// reaching it.
+type nopLoader struct{}
+
+func (nopLoader) Load(ref string) ([]byte, error) { return nil, nil }
+
+func TestParseImportDesc(t *testing.T) {
+	ref, anchor, err := parseImportDesc("sub/intro.svg@header")
+	if err != nil {
+		t.Fatalf("parseImportDesc: %v", err)
+	}
+	if ref != "sub/intro.svg" || anchor != "header" {
+		t.Errorf("got ref=%q anchor=%q, want ref=%q anchor=%q", ref, anchor, "sub/intro.svg", "header")
+	}
+}
+
+func TestParseImportDescMissingAnchor(t *testing.T) {
+	if _, _, err := parseImportDesc("sub/intro.svg"); err == nil {
+		t.Fatal("expected an error for a desc with no \"@anchor\"")
+	}
+}
+
+func TestResolveImportCycleDetection(t *testing.T) {
+	layout := &Layout{
+		Anchors: map[string]AnchorInfo{"header": {Point: geo.Point{X: 1, Y: 1}}},
+	}
+	seen := map[string]bool{"sub/intro.svg": true}
+
+	err := resolveImport(layout, "intro", "sub/intro.svg@header", nopLoader{}, seen)
+	if err == nil {
+		t.Fatal("expected a cycle-detection error")
+	}
+	if !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("error = %q, want it to mention a cycle", err)
+	}
+}
+
+func TestResolveImportUnknownAnchor(t *testing.T) {
+	layout := &Layout{Anchors: map[string]AnchorInfo{}}
+
+	err := resolveImport(layout, "intro", "sub/intro.svg@header", nopLoader{}, map[string]bool{})
+	if err == nil {
+		t.Fatal("expected an error mounting at an anchor the parent doesn't have")
+	}
+}
+
+func TestResolveImportEmptyDesc(t *testing.T) {
+	layout := &Layout{Anchors: map[string]AnchorInfo{}}
+
+	err := resolveImport(layout, "intro", "", nopLoader{}, map[string]bool{})
+	if err == nil {
+		t.Fatal("expected an error for an import with no <desc>")
+	}
+}
+
+// TestMergeImportedLayoutNamespacesAndTranslates covers the translation and
+// "<name>/<key>" namespacing mergeImportedLayout is responsible for,
+// regardless of whether the import declaration came from a <rect> or a
+// <path> -- both shapes funnel through resolveImport/mergeImportedLayout
+// identically, so this is the logic the rect-vs-path dispatch in
+// defineLayoutFromSVGWithImports shares.
+func TestMergeImportedLayoutNamespacesAndTranslates(t *testing.T) {
+	parent := &Layout{
+		Anchors: map[string]AnchorInfo{
+			"mount": {Point: geo.Point{X: 100, Y: 50}},
+		},
+	}
+	child := &Layout{
+		Anchor: geo.Point{X: 0, Y: 0},
+		Anchors: map[string]AnchorInfo{
+			"header": {Point: geo.Point{X: 10, Y: 20}},
+		},
+		Filenames:     map[string]string{"header": "h.png"},
+		PageDimStatic: map[string]DimInfo{"cover": {Dim: geo.Dim{W: 5, H: 6}}},
+	}
+
+	mergeImportedLayout(parent, child, "intro", parent.Anchors["mount"].Point)
+
+	got, ok := parent.Anchors["intro/header"]
+	if !ok {
+		t.Fatal("expected merged anchor under namespaced key \"intro/header\"")
+	}
+	// child.Anchor is the origin of the child's own coordinate space, so
+	// the merged anchor is translated by (origin - child.Anchor).
+	if got.X != 110 || got.Y != 70 {
+		t.Errorf("merged anchor = %+v, want {110 70}", got.Point)
+	}
+	if parent.Filenames["intro/header"] != "h.png" {
+		t.Errorf("filenames not namespaced: %+v", parent.Filenames)
+	}
+	if parent.PageDimStatic["intro/cover"].W != 5 {
+		t.Errorf("page dims not namespaced: %+v", parent.PageDimStatic)
+	}
+}
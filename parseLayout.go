@@ -11,7 +11,7 @@ import (
 	"github.com/timdrysdale/geo"
 )
 
-func DefineLayoutFromSVG(input []byte) (*Layout, error) {
+func DefineLayoutFromSVG(input []byte, opts ...Option) (*Layout, error) {
 
 	var svg Csvg__svg
 	layout := &Layout{}
@@ -33,6 +33,10 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 
 	layout.Anchor = geo.Point{X: 0, Y: 0}
 
+	// getLadderDim reads the ladder's raw width/height straight off the SVG,
+	// in the same pre-scaling unit as every anchor below; the transform
+	// pipeline (defaultTransforms, run at the end of this function)
+	// converts it to points.
 	layoutDim, err := getLadderDim(&svg)
 	if err != nil {
 		return nil, err
@@ -47,9 +51,12 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 	for _, g := range svg.Cg__svg {
 		// get transform applied to layer, if any
 		if g.AttrInkscapeSpacelabel == geo.AnchorsLayer {
-			dx, dy = getTranslate(g.Transform)
+			dx, dy, err = getTransformTranslation(g.Transform)
+			if err != nil {
+				return nil, err
+			}
 
-			layout.Anchors = make(map[string]geo.Point)
+			layout.Anchors = make(map[string]AnchorInfo)
 			layout.Filenames = make(map[string]string)
 
 			for _, r := range g.Cpath__svg {
@@ -62,7 +69,10 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 					return nil, err
 				}
 
-				ddx, ddy := getTranslate(r.Transform)
+				ddx, ddy, err := getTransformTranslation(r.Transform)
+				if err != nil {
+					return nil, fmt.Errorf("anchor at (%f,%f): %v", x, y, err)
+				}
 
 				newX := x + dx + ddx
 				newY := y + dy + ddy
@@ -73,10 +83,23 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 						layout.Anchor = geo.Point{X: newX, Y: newY}
 					} else {
 
-						layout.Anchors[r.Title.String] = geo.Point{X: newX, Y: newY}
+						meta := Metadata{}
+						filename := ""
 
 						if r.Desc != nil {
-							layout.Filenames[r.Title.String] = r.Desc.String
+							meta, filename, err = parseMetadata(r.Desc.String)
+							if err != nil {
+								return nil, fmt.Errorf("anchor %q: %v", r.Title.String, err)
+							}
+						}
+
+						layout.Anchors[r.Title.String] = AnchorInfo{
+							Point:    geo.Point{X: newX, Y: newY},
+							Metadata: meta,
+						}
+
+						if filename != "" {
+							layout.Filenames[r.Title.String] = filename
 						}
 					}
 				} else {
@@ -87,8 +110,8 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 	}
 
 	// look for pageDims
-	layout.PageDimStatic = make(map[string]geo.Dim)
-	layout.PageDimDynamic = make(map[string]geo.DynamicDim)
+	layout.PageDimStatic = make(map[string]DimInfo)
+	layout.PageDimDynamic = make(map[string]DynamicDimInfo)
 	for _, g := range svg.Cg__svg {
 		if g.AttrInkscapeSpacelabel == geo.PagesLayer {
 			for _, r := range g.Crect__svg {
@@ -119,13 +142,27 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 						name = strings.TrimPrefix(fullname, "page-")
 					}
 
+					meta := Metadata{}
+					if r.Desc != nil {
+						meta, _, err = parseMetadata(r.Desc.String)
+						if err != nil {
+							return nil, fmt.Errorf("page %q: %v", fullname, err)
+						}
+					}
+
 					if name != "" {
 						if isDynamic {
-							layout.PageDimDynamic[name] = geo.DynamicDim{Dim: geo.Dim{W: w, H: h},
-								WidthIsDynamic:  w < dynamicDimThreshold,
-								HeightIsDynamic: h < dynamicDimThreshold}
+							layout.PageDimDynamic[name] = DynamicDimInfo{
+								DynamicDim: geo.DynamicDim{Dim: geo.Dim{W: w, H: h},
+									WidthIsDynamic:  w < dynamicDimThreshold,
+									HeightIsDynamic: h < dynamicDimThreshold},
+								Metadata: meta,
+							}
 						} else {
-							layout.PageDimStatic[name] = geo.Dim{W: w, H: h}
+							layout.PageDimStatic[name] = DimInfo{
+								Dim:      geo.Dim{W: w, H: h},
+								Metadata: meta,
+							}
 						}
 					}
 
@@ -136,8 +173,8 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 		}
 	}
 	// look for previousImageDims
-	layout.PreviousImageStatic = make(map[string]geo.Dim)
-	layout.PreviousImageDynamic = make(map[string]geo.DynamicDim)
+	layout.PreviousImageStatic = make(map[string]DimInfo)
+	layout.PreviousImageDynamic = make(map[string]DynamicDimInfo)
 	for _, g := range svg.Cg__svg {
 		if g.AttrInkscapeSpacelabel == geo.ImagesLayer {
 			for _, r := range g.Crect__svg {
@@ -168,13 +205,27 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 						name = strings.TrimPrefix(fullname, "image-previous-")
 					}
 
+					meta := Metadata{}
+					if r.Desc != nil {
+						meta, _, err = parseMetadata(r.Desc.String)
+						if err != nil {
+							return nil, fmt.Errorf("previous image %q: %v", fullname, err)
+						}
+					}
+
 					if name != "" {
 						if isDynamic {
-							layout.PreviousImageDynamic[name] = geo.DynamicDim{Dim: geo.Dim{W: w, H: h},
-								WidthIsDynamic:  w < dynamicDimThreshold,
-								HeightIsDynamic: h < dynamicDimThreshold}
+							layout.PreviousImageDynamic[name] = DynamicDimInfo{
+								DynamicDim: geo.DynamicDim{Dim: geo.Dim{W: w, H: h},
+									WidthIsDynamic:  w < dynamicDimThreshold,
+									HeightIsDynamic: h < dynamicDimThreshold},
+								Metadata: meta,
+							}
 						} else {
-							layout.PreviousImageStatic[name] = geo.Dim{W: w, H: h}
+							layout.PreviousImageStatic[name] = DimInfo{
+								Dim:      geo.Dim{W: w, H: h},
+								Metadata: meta,
+							}
 						}
 					}
 
@@ -185,73 +236,18 @@ func DefineLayoutFromSVG(input []byte) (*Layout, error) {
 		}
 	}
 
-	err = ApplyDocumentUnitsScaleLayout(&svg, layout)
-	if err != nil {
-		return nil, err
-	}
-
-	return layout, nil
-}
-
-func ApplyDocumentUnitsScaleLayout(svg *Csvg__svg, layout *Layout) error {
-
-	// iterate through the structure applying the conversion from
-	// document units to points
-
-	//note we do NOT apply the modification to ladder.DIM because this has its own
-	//units in it and has already been handled.
-
-	units := svg.Cnamedview__sodipodi.AttrInkscapeSpacedocument_dash_units
-
-	sf := float64(1)
-
-	switch units {
-	case "mm":
-		sf = geo.PPMM
-	case "px":
-		sf = geo.PPPX
-	case "pt":
-		sf = 1
-	case "in":
-		sf = geo.PPIN
-	}
-
-	layout.Anchor.X = sf * layout.Anchor.X
-	layout.Anchor.Y = sf * layout.Anchor.Y
-
-	Ytop := layout.Dim.H - layout.Anchor.Y //TODO triple check this sign!
-
-	for k, v := range layout.Anchors {
-		v.X = sf * v.X
-		v.Y = Ytop - (sf * v.Y)
-		layout.Anchors[k] = v
+	cfg := &defineOptions{}
+	for _, opt := range opts {
+		opt(cfg)
 	}
-	for k, v := range layout.PageDimStatic {
-		v.W = sf * v.W
-		v.H = sf * v.H
-		layout.PageDimStatic[k] = v
-
-	}
-	for k, v := range layout.PageDimDynamic {
-		v.Dim.W = sf * v.Dim.W
-		v.Dim.H = sf * v.Dim.H
-		layout.PageDimDynamic[k] = v
-
-	}
-	for k, v := range layout.PreviousImageStatic {
-		v.W = sf * v.W
-		v.H = sf * v.H
-		layout.PreviousImageStatic[k] = v
-
-	}
-	for k, v := range layout.PreviousImageDynamic {
-		v.Dim.W = sf * v.Dim.W
-		v.Dim.H = sf * v.Dim.H
-		layout.PreviousImageDynamic[k] = v
 
+	for _, t := range append(defaultTransforms(&svg), cfg.transforms...) {
+		if err := t.Apply(layout); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return layout, nil
 }
 
 func PrettyPrintLayout(layout *Layout) error {
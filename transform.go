@@ -0,0 +1,363 @@
+package parsesvg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/timdrysdale/geo"
+)
+
+// LayoutTransform is one composable step in converting a freshly parsed
+// Layout from raw SVG document coordinates to points. DefineLayoutFromSVG
+// runs its built-in transforms (unit conversion, viewBox-aware scaling,
+// then the SVG-to-points Y-axis flip) followed by any supplied via
+// Option, in order.
+type LayoutTransform interface {
+	Apply(*Layout) error
+}
+
+// Option configures the transform pipeline DefineLayoutFromSVG runs
+// after parsing.
+type Option func(*defineOptions)
+
+type defineOptions struct {
+	transforms []LayoutTransform
+}
+
+// WithTransform appends t to the pipeline DefineLayoutFromSVG runs after
+// its built-in unit/viewBox/flip conversion.
+func WithTransform(t LayoutTransform) Option {
+	return func(o *defineOptions) {
+		o.transforms = append(o.transforms, t)
+	}
+}
+
+// UnitScaleTransform converts every coordinate and dimension in a Layout
+// from an SVG document unit (mm, cm, px, pt, in, or plain unitless user
+// units) to points.
+type UnitScaleTransform struct {
+	Units string
+}
+
+func (t UnitScaleTransform) scaleFactor() float64 {
+	switch t.Units {
+	case "mm":
+		return geo.PPMM
+	case "cm":
+		return geo.PPMM * 10
+	case "px":
+		return geo.PPPX
+	case "pt":
+		return 1
+	case "in":
+		return geo.PPIN
+	case "":
+		// unitless user-space units: SVGs from tools that don't stamp
+		// inkscape:document-units (Illustrator, Figma, hand-written) are
+		// authored directly in points, so 1 user unit == 1 point.
+		return 1
+	default:
+		return 1
+	}
+}
+
+func (t UnitScaleTransform) Apply(layout *Layout) error {
+	sf := t.scaleFactor()
+
+	layout.Anchor.X = sf * layout.Anchor.X
+	layout.Anchor.Y = sf * layout.Anchor.Y
+	layout.Dim.W = sf * layout.Dim.W
+	layout.Dim.H = sf * layout.Dim.H
+
+	for k, v := range layout.Anchors {
+		v.X = sf * v.X
+		v.Y = sf * v.Y
+		layout.Anchors[k] = v
+	}
+	for k, v := range layout.PageDimStatic {
+		v.W = sf * v.W
+		v.H = sf * v.H
+		layout.PageDimStatic[k] = v
+	}
+	for k, v := range layout.PageDimDynamic {
+		v.Dim.W = sf * v.Dim.W
+		v.Dim.H = sf * v.Dim.H
+		layout.PageDimDynamic[k] = v
+	}
+	for k, v := range layout.PreviousImageStatic {
+		v.W = sf * v.W
+		v.H = sf * v.H
+		layout.PreviousImageStatic[k] = v
+	}
+	for k, v := range layout.PreviousImageDynamic {
+		v.Dim.W = sf * v.Dim.W
+		v.Dim.H = sf * v.Dim.H
+		layout.PreviousImageDynamic[k] = v
+	}
+
+	return nil
+}
+
+// ViewBoxScaleTransform corrects for a mismatch between an SVG's viewBox
+// and its width/height attributes -- something Inkscape keeps in lockstep
+// but other tools (Illustrator, Figma export, hand-written SVG) may not.
+// ViewBoxWidth/ViewBoxHeight are the raw viewBox numbers; DocWidth/DocHeight
+// must be expressed in the SVG's document units, the unit UnitScaleTransform
+// converts from (see parseViewBoxTransform), since only the
+// DocWidth/ViewBoxWidth ratio is used. It rescales layout.Dim along with
+// every anchor and page/image dimension, so it must run after
+// UnitScaleTransform has already converted layout.Dim, same as it expects
+// for the anchors -- per defaultTransforms. A zero-valued
+// ViewBoxScaleTransform is a no-op.
+type ViewBoxScaleTransform struct {
+	ViewBoxWidth, ViewBoxHeight float64
+	DocWidth, DocHeight         float64
+}
+
+func (t ViewBoxScaleTransform) Apply(layout *Layout) error {
+	if t.ViewBoxWidth <= 0 || t.ViewBoxHeight <= 0 || t.DocWidth <= 0 || t.DocHeight <= 0 {
+		return nil
+	}
+
+	sx := t.DocWidth / t.ViewBoxWidth
+	sy := t.DocHeight / t.ViewBoxHeight
+
+	layout.Anchor.X *= sx
+	layout.Anchor.Y *= sy
+	layout.Dim.W *= sx
+	layout.Dim.H *= sy
+
+	for k, v := range layout.Anchors {
+		v.X *= sx
+		v.Y *= sy
+		layout.Anchors[k] = v
+	}
+	for k, v := range layout.PageDimStatic {
+		v.W *= sx
+		v.H *= sy
+		layout.PageDimStatic[k] = v
+	}
+	for k, v := range layout.PageDimDynamic {
+		v.Dim.W *= sx
+		v.Dim.H *= sy
+		layout.PageDimDynamic[k] = v
+	}
+	for k, v := range layout.PreviousImageStatic {
+		v.W *= sx
+		v.H *= sy
+		layout.PreviousImageStatic[k] = v
+	}
+	for k, v := range layout.PreviousImageDynamic {
+		v.Dim.W *= sx
+		v.Dim.H *= sy
+		layout.PreviousImageDynamic[k] = v
+	}
+
+	return nil
+}
+
+// YFlipTransform flips the Y axis of every named anchor, mapping y to
+// (layout.Dim.H - layout.Anchor.Y) - y, converting from SVG's top-left,
+// Y-down coordinate system to the bottom-left, Y-up system callers
+// expect. layout.Anchor itself is left unchanged. It must run after any scaling
+// transform, since it works in already-converted unit space.
+type YFlipTransform struct{}
+
+func (YFlipTransform) Apply(layout *Layout) error {
+	Ytop := layout.Dim.H - layout.Anchor.Y
+
+	for k, v := range layout.Anchors {
+		v.Y = Ytop - v.Y
+		layout.Anchors[k] = v
+	}
+
+	return nil
+}
+
+// TranslateTransform applies a uniform (DX, DY) offset to the reference
+// anchor and every named anchor in a Layout, for callers that need to
+// shift an entire layout, e.g. to align it within a larger page.
+type TranslateTransform struct {
+	DX, DY float64
+}
+
+func (t TranslateTransform) Apply(layout *Layout) error {
+	layout.Anchor.X += t.DX
+	layout.Anchor.Y += t.DY
+
+	for k, v := range layout.Anchors {
+		v.X += t.DX
+		v.Y += t.DY
+		layout.Anchors[k] = v
+	}
+
+	return nil
+}
+
+// AnchorOffsetTransform nudges individual named anchors by a per-anchor
+// offset, for fine-tuning a layout without editing the source SVG.
+type AnchorOffsetTransform struct {
+	Offsets map[string]geo.Point
+}
+
+func (t AnchorOffsetTransform) Apply(layout *Layout) error {
+	for name, offset := range t.Offsets {
+		v, ok := layout.Anchors[name]
+		if !ok {
+			return fmt.Errorf("offset given for unknown anchor %q", name)
+		}
+		v.X += offset.X
+		v.Y += offset.Y
+		layout.Anchors[name] = v
+	}
+	return nil
+}
+
+// ApplyDocumentUnitsScaleLayout builds parsesvg's default coordinate
+// pipeline -- unit conversion, viewBox-aware scaling, then the SVG-to-
+// points Y-axis flip -- and applies it to layout in place.
+func ApplyDocumentUnitsScaleLayout(svg *Csvg__svg, layout *Layout) error {
+	for _, t := range defaultTransforms(svg) {
+		if err := t.Apply(layout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func defaultTransforms(svg *Csvg__svg) []LayoutTransform {
+	units := svg.Cnamedview__sodipodi.AttrInkscapeSpacedocument_dash_units
+
+	transforms := []LayoutTransform{UnitScaleTransform{Units: units}}
+
+	if vb := parseViewBoxTransform(svg); vb != nil {
+		transforms = append(transforms, vb)
+	}
+
+	return append(transforms, YFlipTransform{})
+}
+
+// parseViewBoxTransform builds a ViewBoxScaleTransform from svg's viewBox
+// and width/height attributes. It returns nil if any of them are missing
+// or unparseable, since Inkscape-authored layouts keep viewBox and
+// width/height in lockstep and need no correction.
+//
+// width/height each carry their own unit suffix (e.g. "8.5in", "210mm"),
+// which is frequently not inkscape:document-units at all -- tools other
+// than Inkscape rarely stamp that attribute, leaving UnitScaleTransform's
+// factor at 1. ViewBoxScaleTransform.Apply runs after UnitScaleTransform
+// and only divides DocWidth/DocHeight by ViewBoxWidth/ViewBoxHeight, so
+// DocWidth/DocHeight must be expressed in document units, the unit
+// UnitScaleTransform converted the anchors from: width/height's own
+// declared unit converted to points, then divided by the same
+// document-units factor UnitScaleTransform applied.
+func parseViewBoxTransform(svg *Csvg__svg) *ViewBoxScaleTransform {
+	fields := strings.Fields(svg.ViewBox)
+	if len(fields) != 4 {
+		return nil
+	}
+
+	vbw, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil
+	}
+	vbh, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return nil
+	}
+
+	docwRaw, docwUnit, err := parseLengthWithUnit(svg.Width)
+	if err != nil {
+		return nil
+	}
+	dochRaw, dochUnit, err := parseLengthWithUnit(svg.Height)
+	if err != nil {
+		return nil
+	}
+
+	docUnitsFactor := UnitScaleTransform{
+		Units: svg.Cnamedview__sodipodi.AttrInkscapeSpacedocument_dash_units,
+	}.scaleFactor()
+
+	docw := docwRaw * UnitScaleTransform{Units: docwUnit}.scaleFactor() / docUnitsFactor
+	doch := dochRaw * UnitScaleTransform{Units: dochUnit}.scaleFactor() / docUnitsFactor
+
+	return &ViewBoxScaleTransform{
+		ViewBoxWidth:  vbw,
+		ViewBoxHeight: vbh,
+		DocWidth:      docw,
+		DocHeight:     doch,
+	}
+}
+
+// parseLengthWithUnit splits an SVG length attribute (e.g. "210mm",
+// "8.5in", "816") into its numeric value and unit suffix ("" for a bare,
+// unitless number).
+func parseLengthWithUnit(s string) (value float64, unit string, err error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+
+	value, err = strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return value, strings.TrimSpace(trimmed[i:]), nil
+}
+
+// matrixIdentityTolerance is how far a matrix(...)'s a,b,c,d components may
+// stray from the identity (1 0 0 1) and still be treated as pure
+// translation, absorbing the rounding Inkscape and other tools leave
+// behind when they round-trip a plain translate().
+const matrixIdentityTolerance = 1e-6
+
+// getTransformTranslation extracts the net (dx, dy) translation from an
+// SVG transform attribute, supporting both "translate(x y)" (via
+// getTranslate) and "matrix(a b c d e f)", whose e, f components give the
+// translation applied to the origin. A matrix(...) whose a, b, c, d
+// components aren't the identity encodes a scale, rotation, or skew this
+// function doesn't apply to the point it's added to, so it errors instead
+// of silently returning a wrong position.
+func getTransformTranslation(transform string) (dx, dy float64, err error) {
+	trimmed := strings.TrimSpace(transform)
+	if !strings.HasPrefix(trimmed, "matrix(") {
+		dx, dy = getTranslate(transform)
+		return dx, dy, nil
+	}
+
+	args := parseTransformArgs(trimmed, "matrix(")
+	if len(args) != 6 {
+		return 0, 0, nil
+	}
+
+	a, b, c, d := args[0], args[1], args[2], args[3]
+	if math.Abs(a-1) > matrixIdentityTolerance || math.Abs(b) > matrixIdentityTolerance ||
+		math.Abs(c) > matrixIdentityTolerance || math.Abs(d-1) > matrixIdentityTolerance {
+		return 0, 0, fmt.Errorf("transform %q has a scale, rotation, or skew component, which is not supported", transform)
+	}
+
+	return args[4], args[5], nil
+}
+
+func parseTransformArgs(transform, prefix string) []float64 {
+	body := strings.TrimSuffix(strings.TrimPrefix(transform, prefix), ")")
+	fields := strings.FieldsFunc(body, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	args := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil
+		}
+		args = append(args, v)
+	}
+	return args
+}
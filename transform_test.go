@@ -0,0 +1,229 @@
+package parsesvg
+
+import (
+	"testing"
+
+	"github.com/timdrysdale/geo"
+)
+
+// TestUnitScaleThenViewBoxScale exercises the scenario the request targets:
+// a non-Inkscape SVG where document-units is "mm" but viewBox and
+// width/height disagree (viewBox="0 0 100 100", width/height="200mm"), so
+// 1 viewBox unit covers 2mm of page. An anchor at raw x=50 should land at
+// 50 * 2mm/unit * pt/mm, not that figure multiplied by the mm-to-pt factor
+// a second time.
+func TestUnitScaleThenViewBoxScale(t *testing.T) {
+	layout := &Layout{
+		Anchors: map[string]AnchorInfo{
+			"a": {Point: geo.Point{X: 50, Y: 0}},
+		},
+	}
+
+	pipeline := []LayoutTransform{
+		UnitScaleTransform{Units: "mm"},
+		ViewBoxScaleTransform{
+			ViewBoxWidth:  100,
+			ViewBoxHeight: 100,
+			DocWidth:      200,
+			DocHeight:     200,
+		},
+	}
+
+	for _, transform := range pipeline {
+		if err := transform.Apply(layout); err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+	}
+
+	want := 50 * 2 * geo.PPMM
+	got := layout.Anchors["a"].X
+
+	if diff := got - want; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("anchor x = %v, want %v (the mm-to-pt factor must apply exactly once)", got, want)
+	}
+}
+
+func TestViewBoxScaleTransformIsNoopWhenUnset(t *testing.T) {
+	layout := &Layout{
+		Anchors: map[string]AnchorInfo{
+			"a": {Point: geo.Point{X: 12, Y: 34}},
+		},
+	}
+
+	if err := (ViewBoxScaleTransform{}).Apply(layout); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if got := layout.Anchors["a"]; got.X != 12 || got.Y != 34 {
+		t.Errorf("zero-valued ViewBoxScaleTransform changed anchor to %+v", got)
+	}
+}
+
+func TestYFlipTransform(t *testing.T) {
+	layout := &Layout{
+		Dim:    geo.Dim{H: 100},
+		Anchor: geo.Point{Y: 10},
+		Anchors: map[string]AnchorInfo{
+			"a": {Point: geo.Point{Y: 20}},
+		},
+	}
+
+	if err := (YFlipTransform{}).Apply(layout); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// Ytop = Dim.H - Anchor.Y = 90; flipped Y = Ytop - v.Y = 70
+	if got := layout.Anchors["a"].Y; got != 70 {
+		t.Errorf("flipped anchor y = %v, want 70", got)
+	}
+}
+
+// TestDefaultPipelineScalesDimBeforeYFlip exercises the full unit-scale ->
+// viewBox-scale -> Y-flip pipeline with a genuine viewBox/width mismatch and
+// a nonzero layout.Dim, guarding against layout.Dim being left in raw units
+// while Anchor/Anchors are already in points: document-units="mm",
+// viewBox="0 0 100 100", width/height="200mm" (1 viewBox unit == 2mm), and a
+// ladder 80 viewBox-units tall. Dim.H must scale by the same 2mm/unit ratio
+// as every anchor, or YFlipTransform's Ytop := Dim.H - Anchor.Y mixes scaled
+// and unscaled units and every flipped Y comes out wrong.
+func TestDefaultPipelineScalesDimBeforeYFlip(t *testing.T) {
+	layout := &Layout{
+		Dim:    geo.Dim{H: 80},
+		Anchor: geo.Point{Y: 10},
+		Anchors: map[string]AnchorInfo{
+			"a": {Point: geo.Point{Y: 20}},
+		},
+	}
+
+	pipeline := []LayoutTransform{
+		UnitScaleTransform{Units: "mm"},
+		ViewBoxScaleTransform{
+			ViewBoxWidth:  100,
+			ViewBoxHeight: 100,
+			DocWidth:      200,
+			DocHeight:     200,
+		},
+		YFlipTransform{},
+	}
+
+	for _, transform := range pipeline {
+		if err := transform.Apply(layout); err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+	}
+
+	// Every raw value is scaled by the same factor (mm-to-pt times the
+	// viewBox ratio) before the flip: with u := 2*geo.PPMM, Dim.H=80u,
+	// Anchor.Y=10u, anchor "a".Y=20u.
+	// Ytop = Dim.H - Anchor.Y = 70u; flipped Y = Ytop - v.Y = 50u.
+	u := 2 * geo.PPMM
+	want := 50 * u
+	got := layout.Anchors["a"].Y
+
+	if diff := got - want; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("flipped anchor y = %v, want %v (layout.Dim must be scaled in step with Anchor)", got, want)
+	}
+}
+
+func TestParseLengthWithUnit(t *testing.T) {
+	cases := []struct {
+		in        string
+		wantValue float64
+		wantUnit  string
+	}{
+		{"210mm", 210, "mm"},
+		{"8.5in", 8.5, "in"},
+		{"816", 816, ""},
+		{"-5.25pt", -5.25, "pt"},
+	}
+
+	for _, c := range cases {
+		value, unit, err := parseLengthWithUnit(c.in)
+		if err != nil {
+			t.Errorf("parseLengthWithUnit(%q): %v", c.in, err)
+			continue
+		}
+		if value != c.wantValue || unit != c.wantUnit {
+			t.Errorf("parseLengthWithUnit(%q) = (%v, %q), want (%v, %q)", c.in, value, unit, c.wantValue, c.wantUnit)
+		}
+	}
+}
+
+// TestParseViewBoxTransformNoDocumentUnits exercises the scenario this
+// request targets: a completely ordinary Illustrator/CSS-style export with
+// no inkscape:document-units attribute at all (so UnitScaleTransform's
+// factor is 1) and width/height given in a physical unit that disagrees
+// with the viewBox. width="8.5in" height="11in" viewBox="0 0 816 1056"
+// must scale anchors by 8.5in-in-points/816 per viewBox unit (0.75pt/unit),
+// not by the bare numeric ratio 8.5/816 that ignores the "in" suffix.
+func TestParseViewBoxTransformNoDocumentUnits(t *testing.T) {
+	var svg Csvg__svg
+	svg.ViewBox = "0 0 816 1056"
+	svg.Width = "8.5in"
+	svg.Height = "11in"
+
+	vb := parseViewBoxTransform(&svg)
+	if vb == nil {
+		t.Fatal("expected a non-nil ViewBoxScaleTransform")
+	}
+
+	layout := &Layout{
+		Anchors: map[string]AnchorInfo{
+			"a": {Point: geo.Point{X: 816, Y: 0}},
+		},
+	}
+
+	pipeline := []LayoutTransform{UnitScaleTransform{Units: ""}, vb}
+	for _, transform := range pipeline {
+		if err := transform.Apply(layout); err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+	}
+
+	want := 8.5 * geo.PPIN
+	got := layout.Anchors["a"].X
+
+	if diff := got - want; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("anchor x = %v, want %v (width's own \"in\" suffix must be converted to points)", got, want)
+	}
+}
+
+func TestGetTransformTranslationIdentityMatrix(t *testing.T) {
+	dx, dy, err := getTransformTranslation("matrix(1 0 0 1 12 34)")
+	if err != nil {
+		t.Fatalf("getTransformTranslation: %v", err)
+	}
+	if dx != 12 || dy != 34 {
+		t.Errorf("got (%v, %v), want (12, 34)", dx, dy)
+	}
+}
+
+// TestGetTransformTranslationRejectsScaleComponent guards against the
+// request's "honour matrix(...)" support silently dropping a, b, c, d: a
+// matrix with a real scale component must error rather than return a
+// translation that ignores it.
+func TestGetTransformTranslationRejectsScaleComponent(t *testing.T) {
+	_, _, err := getTransformTranslation("matrix(2 0 0 2 12 34)")
+	if err == nil {
+		t.Fatal("expected an error for a matrix with a non-identity scale component")
+	}
+}
+
+func TestGetTransformTranslationRejectsRotateComponent(t *testing.T) {
+	_, _, err := getTransformTranslation("matrix(0 1 -1 0 12 34)")
+	if err == nil {
+		t.Fatal("expected an error for a matrix with a non-identity rotation component")
+	}
+}
+
+func TestAnchorOffsetTransformUnknownAnchor(t *testing.T) {
+	layout := &Layout{Anchors: map[string]AnchorInfo{}}
+
+	err := AnchorOffsetTransform{
+		Offsets: map[string]geo.Point{"missing": {X: 1, Y: 1}},
+	}.Apply(layout)
+
+	if err == nil {
+		t.Fatal("expected an error for an offset on an unknown anchor")
+	}
+}
@@ -2,6 +2,12 @@ package parsesvg
 
 import "github.com/timdrysdale/geo"
 
+// TextField and Ladder deliberately carry no Metadata field: neither type
+// is constructed by anything in this package (no parseTextFields/
+// parseLadder exists alongside parseLayout.go's anchor/page/image
+// handling), so front-matter support for them belongs wherever that
+// parsing actually lives, not here. AnchorInfo/DimInfo/DynamicDimInfo
+// carry Metadata because parseLayout.go wires them to parseMetadata.
 type TextField struct {
 	Rect        geo.Rect //Corner.X/Y, Dim.W/H
 	ID          string
@@ -15,3 +21,20 @@ type Ladder struct {
 	ID         string
 	TextFields []TextField
 }
+
+// Layout is the parsed representation of a layout SVG produced by
+// DefineLayoutFromSVG: anchor positions, page and previous-image
+// dimensions, and the filenames associated with them, all in document
+// points.
+type Layout struct {
+	ID                   string
+	Anchor               geo.Point
+	Dim                  geo.Dim
+	Anchors              map[string]AnchorInfo
+	Filenames            map[string]string
+	PageDimStatic        map[string]DimInfo
+	PageDimDynamic       map[string]DynamicDimInfo
+	PreviousImageStatic  map[string]DimInfo
+	PreviousImageDynamic map[string]DynamicDimInfo
+	Imports              []ImportedLayout
+}